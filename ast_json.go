@@ -0,0 +1,276 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ToJSONObject converts an AstFrame tree into a plain
+// map[string]interface{} suitable for encoding/json, using the layout
+// {name, value, children, meta:{pos,line,col,endPos}}. The result can be turned
+// back into an equivalent tree with ASTFromJSONObject.
+func (f *AstFrame) ToJSONObject() map[string]interface{} {
+	children := make([]interface{}, len(f.Children))
+	for i, child := range f.Children {
+		children[i] = child.ToJSONObject()
+	}
+
+	line, col := f.LineCol()
+
+	return map[string]interface{}{
+		"name": string(f.Name),
+		"value": dataToJSONValue(f.Data),
+		"children": children,
+		"meta": map[string]interface{}{
+			"pos": f.Pos,
+			"line": line,
+			"col": col,
+			"endPos": f.EndPos,
+		},
+	}
+}
+
+// dataToJSONValue converts an AstFrame's Data map into a JSON-safe value,
+// recursively converting any *AstFrame or []*AstFrame entries (e.g.
+// "Callee", "Arguments") into their own JSON objects.
+func dataToJSONValue(data map[string]interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	out := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		switch v := value.(type) {
+		case *AstFrame:
+			out[key] = v.ToJSONObject()
+		case []*AstFrame:
+			items := make([]interface{}, len(v))
+			for i, item := range v {
+				items[i] = item.ToJSONObject()
+			}
+			out[key] = items
+		default:
+			out[key] = v
+		}
+	}
+
+	return out
+}
+
+// ASTFromJSONObject reconstructs an AstFrame tree from the map produced by
+// ToJSONObject (or the equivalent, decoded from JSON with encoding/json).
+func ASTFromJSONObject(obj map[string]interface{}) (*AstFrame, error) {
+	return astFromJSONObject(obj, nil)
+}
+
+func astFromJSONObject(obj map[string]interface{}, parent *AstFrame) (*AstFrame, error) {
+	name, ok := obj["name"].(string)
+	if !ok {
+		return nil, errors.New("ASTFromJSONObject: node is missing a string \"name\" field")
+	}
+
+	frame := &AstFrame{
+		Name: TokenName(name),
+		Parent: parent,
+	}
+
+	if meta, ok := obj["meta"].(map[string]interface{}); ok {
+		if pos, ok := meta["pos"].(float64); ok {
+			frame.Pos = int(pos)
+		}
+		if line, ok := meta["line"].(float64); ok {
+			frame.Line = int(line)
+		}
+		if col, ok := meta["col"].(float64); ok {
+			frame.Col = int(col)
+		}
+		if endPos, ok := meta["endPos"].(float64); ok {
+			frame.EndPos = int(endPos)
+		}
+	}
+
+	if rawChildren, ok := obj["children"].([]interface{}); ok {
+		frame.Children = make([]*AstFrame, len(rawChildren))
+		for i, rawChild := range rawChildren {
+			childObj, ok := rawChild.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("ASTFromJSONObject: children[%d] is not a JSON object", i)
+			}
+
+			child, err := astFromJSONObject(childObj, frame)
+			if err != nil {
+				return nil, err
+			}
+			frame.Children[i] = child
+		}
+	}
+
+	data, err := dataFromJSONValue(obj["value"], frame)
+	if err != nil {
+		return nil, err
+	}
+	frame.Data = data
+
+	return frame, nil
+}
+
+// dataFromJSONValue is the inverse of dataToJSONValue: it turns the decoded
+// "value" object back into a Data map, recursively reconstructing any
+// nested AST nodes or node lists.
+func dataFromJSONValue(value interface{}, parent *AstFrame) (map[string]interface{}, error) {
+	if value == nil {
+		return EMPTY_DATA, nil
+	}
+
+	raw, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("ASTFromJSONObject: \"value\" must be a JSON object")
+	}
+
+	out := make(map[string]interface{}, len(raw))
+	for key, v := range raw {
+		switch nested := v.(type) {
+		case map[string]interface{}:
+			child, err := astFromJSONObject(nested, parent)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = child
+		case []interface{}:
+			items := make([]*AstFrame, len(nested))
+			for i, item := range nested {
+				itemObj, ok := item.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("ASTFromJSONObject: value[%q][%d] is not a JSON object", key, i)
+				}
+
+				child, err := astFromJSONObject(itemObj, parent)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = child
+			}
+			out[key] = items
+		default:
+			out[key] = v
+		}
+	}
+
+	return out, nil
+}
+
+// Equals reports whether f and other are structurally equivalent -- same
+// names, Data and children, recursively -- and returns a description of the
+// first point where they diverge. When ignoreTokenPosition is true,
+// differences in Pos, Line, Col and EndPos are not considered a divergence, which is useful when
+// comparing a freshly parsed tree against one that round-tripped through
+// JSON built from a different (but equivalent) source string.
+func (f *AstFrame) Equals(other *AstFrame, ignoreTokenPosition bool) (bool, string) {
+	return astEquals(f, other, ignoreTokenPosition, "root")
+}
+
+func astEquals(a, b *AstFrame, ignoreTokenPosition bool, path string) (bool, string) {
+	if a == nil || b == nil {
+		if a == b {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%s: one side is nil", path)
+	}
+
+	if a.Name != b.Name {
+		return false, fmt.Sprintf("%s: name mismatch %q != %q", path, a.Name, b.Name)
+	}
+
+	if !ignoreTokenPosition {
+		if a.Pos != b.Pos || a.Line != b.Line || a.Col != b.Col || a.EndPos != b.EndPos {
+			return false, fmt.Sprintf(
+				"%s: position mismatch {%d,%d,%d,%d} != {%d,%d,%d,%d}",
+				path, a.Pos, a.Line, a.Col, a.EndPos, b.Pos, b.Line, b.Col, b.EndPos,
+			)
+		}
+	}
+
+	if ok, reason := dataEquals(a.Data, b.Data, ignoreTokenPosition, path); !ok {
+		return false, reason
+	}
+
+	if len(a.Children) != len(b.Children) {
+		return false, fmt.Sprintf("%s: child count mismatch %d != %d", path, len(a.Children), len(b.Children))
+	}
+
+	for i := range a.Children {
+		childPath := fmt.Sprintf("%s.children[%d]", path, i)
+		if ok, reason := astEquals(a.Children[i], b.Children[i], ignoreTokenPosition, childPath); !ok {
+			return false, reason
+		}
+	}
+
+	return true, ""
+}
+
+func dataEquals(a, b map[string]interface{}, ignoreTokenPosition bool, path string) (bool, string) {
+	if len(a) != len(b) {
+		return false, fmt.Sprintf("%s: data key count mismatch %d != %d", path, len(a), len(b))
+	}
+
+	for key, aValue := range a {
+		bValue, ok := b[key]
+		if !ok {
+			return false, fmt.Sprintf("%s: data[%q] missing on right side", path, key)
+		}
+
+		valuePath := fmt.Sprintf("%s.data[%q]", path, key)
+
+		switch av := aValue.(type) {
+		case *AstFrame:
+			bv, ok := bValue.(*AstFrame)
+			if !ok {
+				return false, fmt.Sprintf("%s: type mismatch, expected *AstFrame", valuePath)
+			}
+			if ok, reason := astEquals(av, bv, ignoreTokenPosition, valuePath); !ok {
+				return false, reason
+			}
+		case []*AstFrame:
+			bv, ok := bValue.([]*AstFrame)
+			if !ok {
+				return false, fmt.Sprintf("%s: type mismatch, expected []*AstFrame", valuePath)
+			}
+			if len(av) != len(bv) {
+				return false, fmt.Sprintf("%s: length mismatch %d != %d", valuePath, len(av), len(bv))
+			}
+			for i := range av {
+				itemPath := fmt.Sprintf("%s[%d]", valuePath, i)
+				if ok, reason := astEquals(av[i], bv[i], ignoreTokenPosition, itemPath); !ok {
+					return false, reason
+				}
+			}
+		default:
+			if !numericallyEqual(aValue, bValue) && !reflect.DeepEqual(aValue, bValue) {
+				return false, fmt.Sprintf("%s: value mismatch %#v != %#v", valuePath, aValue, bValue)
+			}
+		}
+	}
+
+	return true, ""
+}
+
+// numericallyEqual lets int and float64 compare equal, since a value that
+// round-trips through encoding/json comes back as float64 even if it started
+// out as an int (e.g. INTEGER_LITERAL's "Content").
+func numericallyEqual(a, b interface{}) bool {
+	af, aok := asFloat64(a)
+	bf, bok := asFloat64(b)
+	return aok && bok && af == bf
+}
+
+func asFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,24 @@
+// Command goparser is a small demo driver for the parser package: it parses
+// a hard-coded program and prints the resulting AST.
+package main
+
+import (
+	"fmt"
+
+	"github.com/1egoman/go-parser"
+)
+
+func main() {
+	data := `do
+		func(a<b> do 1 end)
+		foo()
+	end`
+
+	ast, err := parser.Parse(data)
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		fmt.Println("Ast:")
+		parser.PrintAst(ast, "")
+	}
+}
@@ -0,0 +1,254 @@
+package parser
+
+import (
+	"errors"
+	"regexp"
+)
+
+// BINARY_EXPRESSION and PREFIX_EXPRESSION are synthesized by the expression
+// folder below; they never come from a Token match directly.
+const (
+	BINARY_EXPRESSION TokenName = "BINARY_EXPRESSION"
+	PREFIX_EXPRESSION TokenName = "PREFIX_EXPRESSION"
+)
+
+// Associativity controls which side a run of equal-precedence infix
+// operators folds towards.
+type Associativity int
+const (
+	LeftAssociative Associativity = iota
+	RightAssociative
+)
+
+type infixOperator struct {
+	Precedence int
+	Associativity Associativity
+}
+
+// Grammar is a user-assembled set of tokens and operator precedences. Pass
+// one to NewParser to parse with it; DefaultGrammar returns the
+// call/block/arg-list grammar this package has always understood.
+type Grammar struct {
+	Tokens []Token
+	Infix map[TokenName]infixOperator
+	Prefix map[TokenName]bool
+}
+
+// NewGrammar returns an empty Grammar with no tokens or operators
+// registered.
+func NewGrammar() *Grammar {
+	return &Grammar{
+		Infix: map[TokenName]infixOperator{},
+		Prefix: map[TokenName]bool{},
+	}
+}
+
+// Register adds t to the set of tokens the grammar recognizes. Tokens are
+// tried in registration order, so earlier, more specific tokens should be
+// registered before broader ones they could be confused with.
+func (g *Grammar) Register(t Token) {
+	g.Tokens = append(g.Tokens, t)
+}
+
+// RegisterInfix marks name as a binary operator token with the given
+// precedence (higher binds tighter) and associativity, so that expressions
+// inside call arguments and arg-lists fold it into a BINARY_EXPRESSION node.
+func (g *Grammar) RegisterInfix(name TokenName, precedence int, assoc Associativity) {
+	g.Infix[name] = infixOperator{Precedence: precedence, Associativity: assoc}
+}
+
+// RegisterPrefix marks name as a unary prefix operator token, so that e.g.
+// `-x` folds into a PREFIX_EXPRESSION node instead of being left as two
+// unrelated siblings.
+func (g *Grammar) RegisterPrefix(name TokenName) {
+	g.Prefix[name] = true
+}
+
+// DefaultGrammar returns a Grammar preloaded with this package's original
+// call(...)/block do...end/arg-list<...> tokens and no operators, matching
+// the behavior of Parser before grammars became pluggable.
+func DefaultGrammar() *Grammar {
+	g := NewGrammar()
+	for _, t := range builtinTokens {
+		g.Register(t)
+	}
+	return g
+}
+
+// NewExpressionGrammar returns DefaultGrammar extended with a conventional
+// set of arithmetic, comparison and logical infix operators, so the parser
+// can be used as a general expression-language front end out of the box.
+// `<` and `>` are intentionally left unregistered since the built-in
+// grammar already uses them to delimit arg-lists.
+func NewExpressionGrammar() *Grammar {
+	g := DefaultGrammar()
+
+	operator := func(name TokenName, shape string) Token {
+		return Token{Name: name, Shape: regexp.MustCompile(shape)}
+	}
+
+	g.Register(operator("EQUAL", `^==`))
+	g.Register(operator("NOT_EQUAL", `^!=`))
+	g.Register(operator("AND", `^&&`))
+	g.Register(operator("OR", `^\|\|`))
+	g.Register(operator("NOT", `^!`))
+	g.Register(operator("PLUS", `^\+`))
+	g.Register(operator("MINUS", `^-`))
+	g.Register(operator("TIMES", `^\*`))
+	g.Register(operator("DIVIDE", `^/`))
+
+	g.RegisterInfix("OR", 1, LeftAssociative)
+	g.RegisterInfix("AND", 2, LeftAssociative)
+	g.RegisterInfix("EQUAL", 3, LeftAssociative)
+	g.RegisterInfix("NOT_EQUAL", 3, LeftAssociative)
+	g.RegisterInfix("PLUS", 4, LeftAssociative)
+	g.RegisterInfix("MINUS", 4, LeftAssociative)
+	g.RegisterInfix("TIMES", 5, LeftAssociative)
+	g.RegisterInfix("DIVIDE", 5, LeftAssociative)
+
+	g.RegisterPrefix("NOT")
+	g.RegisterPrefix("MINUS")
+
+	return g
+}
+
+// foldArguments turns the already-parsed children of a call/arg-list/block
+// frame into its folded body -- a call or arg-list's Arguments, or a
+// block's Statements -- by folding each comma-separated segment (a block
+// has none, so it's a single segment spanning the whole body) into an
+// expression via precedence climbing (see climbExpr). inName and outName
+// are skipped (they're the frame's own delimiter tokens, e.g.
+// CALL_IN/CALL_OUT or BLOCK_IN/BLOCK_OUT), matching the filtering the
+// original flat-argument-list implementation did.
+func foldArguments(ast *AstFrame, g *Grammar, src *Source, pos Position, inName, outName TokenName) ([]*AstFrame, error) {
+	var segments [][]*AstFrame
+	var current []*AstFrame
+
+	lastItemWasAnItemSeperator := true
+	for index, child := range ast.Children {
+		if child.Name == WHITESPACE { continue }
+		if index == 0 && child.Name == inName { continue }
+		if index == len(ast.Children)-1 && child.Name == outName { continue }
+
+		if child.Name == ITEM_SEPERATOR {
+			if lastItemWasAnItemSeperator {
+				return nil, ParseError(src, pos, "Two item seperators were found in a row!")
+			}
+			lastItemWasAnItemSeperator = true
+			segments = append(segments, current)
+			current = nil
+			continue
+		}
+
+		lastItemWasAnItemSeperator = false
+		current = append(current, child)
+	}
+	if len(current) > 0 {
+		segments = append(segments, current)
+	}
+
+	// Fold each comma-separated segment via precedence climbing. A segment
+	// can contain more than one expression back to back with no operator
+	// between them (e.g. the built-in grammar has no infix operators at
+	// all, so `a<b>` inside a call is two adjacent items); climbExpr only
+	// consumes what its registered operators connect, so loop it over
+	// whatever's left until the segment is spent, same as the grammar-less
+	// original behavior of treating every non-separator child as its own
+	// Argument.
+	data := make([]*AstFrame, 0, len(segments))
+	for _, segment := range segments {
+		for len(segment) > 0 {
+			folded, rest, err := climbExpr(segment, g, 0)
+			if err != nil {
+				return nil, ParseError(src, pos, err.Error())
+			}
+			data = append(data, folded)
+			segment = rest
+		}
+	}
+
+	return data, nil
+}
+
+// parsePrimary consumes a single operand off the front of items, unwrapping
+// any registered prefix operator first.
+func parsePrimary(items []*AstFrame, g *Grammar) (*AstFrame, []*AstFrame, error) {
+	if len(items) == 0 {
+		return nil, nil, errors.New("expected an expression")
+	}
+
+	if g.Prefix[items[0].Name] {
+		opToken := items[0]
+
+		operand, rest, err := parsePrimary(items[1:], g)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		frame := &AstFrame{
+			Name: PREFIX_EXPRESSION,
+			Data: map[string]interface{}{"Operator": string(opToken.Name), "Operand": operand},
+			Pos: opToken.Pos,
+			Line: opToken.Line,
+			Col: opToken.Col,
+			EndPos: operand.EndPos,
+		}
+		operand.Parent = frame
+
+		return frame, rest, nil
+	}
+
+	return items[0], items[1:], nil
+}
+
+// climbExpr implements precedence climbing over a flat slice of already-
+// parsed operand/operator AstFrames: parse a primary, then while the next
+// token is a registered infix operator with precedence >= minPrec, consume
+// it and recurse for the right-hand side -- at the operator's precedence+1
+// for a left-associative operator (so a run of equal-precedence operators
+// folds leftward through this loop instead of recursing), or at its
+// precedence for a right-associative one (so it folds rightward instead).
+func climbExpr(items []*AstFrame, g *Grammar, minPrec int) (*AstFrame, []*AstFrame, error) {
+	left, rest, err := parsePrimary(items, g)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for len(rest) > 0 {
+		op, ok := g.Infix[rest[0].Name]
+		if !ok || op.Precedence < minPrec {
+			break
+		}
+
+		opToken := rest[0]
+
+		nextMinPrec := op.Precedence + 1
+		if op.Associativity == RightAssociative {
+			nextMinPrec = op.Precedence
+		}
+
+		right, newRest, err := climbExpr(rest[1:], g, nextMinPrec)
+		if err != nil {
+			return nil, nil, err
+		}
+		rest = newRest
+
+		frame := &AstFrame{
+			Name: BINARY_EXPRESSION,
+			Data: map[string]interface{}{
+				"Operator": string(opToken.Name),
+				"Left": left,
+				"Right": right,
+			},
+			Pos: left.Pos,
+			Line: left.Line,
+			Col: left.Col,
+			EndPos: right.EndPos,
+		}
+		left.Parent = frame
+		right.Parent = frame
+		left = frame
+	}
+
+	return left, rest, nil
+}
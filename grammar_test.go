@@ -0,0 +1,96 @@
+package parser
+
+import "testing"
+
+// Covers chunk0-3's core contract: operator precedence climbs correctly (a
+// higher-precedence operator binds tighter than a lower one around it), and
+// same-precedence operators fold left-associatively by default.
+func TestExpressionGrammarFoldsByPrecedence(t *testing.T) {
+	ast, err := NewParser(NewExpressionGrammar()).Parse("1+2*3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := ast.Statements()
+	if len(statements) != 1 {
+		t.Fatalf("expected a single folded statement, got %d", len(statements))
+	}
+
+	top := statements[0]
+	if top.Name != BINARY_EXPRESSION || top.Data["Operator"] != "PLUS" {
+		t.Fatalf("expected top-level PLUS, got %s %v", top.Name, top.Data["Operator"])
+	}
+
+	right, ok := top.Data["Right"].(*AstFrame)
+	if !ok || right.Name != BINARY_EXPRESSION || right.Data["Operator"] != "TIMES" {
+		t.Fatalf("expected 2*3 to fold tighter than +, got %+v", top.Data["Right"])
+	}
+}
+
+func TestExpressionGrammarFoldsLeftAssociatively(t *testing.T) {
+	ast, err := NewParser(NewExpressionGrammar()).Parse("1-2-3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	top := ast.Statements()[0]
+	left, ok := top.Data["Left"].(*AstFrame)
+	if !ok || left.Name != BINARY_EXPRESSION {
+		t.Fatalf("expected (1-2)-3 to nest on the left, got %+v", top.Data["Left"])
+	}
+
+	if right, ok := top.Data["Right"].(*AstFrame); !ok || right.Name == BINARY_EXPRESSION {
+		t.Fatalf("expected Right to be a leaf, got %+v", top.Data["Right"])
+	}
+}
+
+// Covers the gap this review found: a bare top-level expression (no
+// enclosing call/arg-list/block) still folds via precedence climbing.
+func TestBareTopLevelExpressionFolds(t *testing.T) {
+	ast, err := NewParser(NewExpressionGrammar()).Parse("1+2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statements := ast.Statements()
+	if len(statements) != 1 || statements[0].Name != BINARY_EXPRESSION {
+		t.Fatalf("expected one folded BINARY_EXPRESSION, got %d statements: %+v", len(statements), statements)
+	}
+}
+
+// Covers the same gap inside a do...end block.
+func TestBlockLevelExpressionFolds(t *testing.T) {
+	ast, err := NewParser(NewExpressionGrammar()).Parse("do 1+2 end")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	block := ast.Statements()[0]
+	statements := block.Statements()
+	if len(statements) != 1 || statements[0].Name != BINARY_EXPRESSION {
+		t.Fatalf("expected one folded BINARY_EXPRESSION inside the block, got %d statements: %+v", len(statements), statements)
+	}
+}
+
+// `<` and `>` are intentionally left unregistered by NewExpressionGrammar so
+// arg-lists keep working; confirm two adjacent items with no operator
+// between them -- the identifier and the arg-list it precedes -- still fold
+// to two separate Arguments instead of erroring as an incomplete expression.
+func TestUnseparatedArgListItemsStillFoldSeparately(t *testing.T) {
+	ast, err := NewParser(NewExpressionGrammar()).Parse("foo(a<b>)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	call := ast.Statements()[0]
+	args := call.Arguments()
+	if len(args) != 2 {
+		t.Fatalf("expected 2 arguments (a, then its arg-list), got %d: %+v", len(args), args)
+	}
+	if name, ok := args[0].StringValue(); !ok || name != "a" {
+		t.Fatalf("expected first argument to be identifier \"a\", got %+v", args[0])
+	}
+	if args[1].Name != ARG_LIST_EXPRESSION {
+		t.Fatalf("expected second argument to be an ARG_LIST_EXPRESSION, got %s", args[1].Name)
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// lexChunkSize is how many bytes the lexer reads from its underlying
+// io.Reader at a time when its lookahead buffer runs out. It's deliberately
+// small -- most tokens resolve within a few bytes of lookahead, and the
+// buffer grows on demand for the rare token (e.g. a long string literal)
+// that needs more.
+const lexChunkSize = 64
+
+// LexToken is a single token produced by Lex/LexAll: its Name, the exact
+// source text it matched, and the Position its first byte occurs at.
+type LexToken struct {
+	Name TokenName
+	Text string
+	Pos Position
+}
+
+// Lex streams g's tokens from r. Rather than running each token's regexp
+// against the entire remaining input on every step, it keeps a small
+// lookahead buffer drawn from r via bufio.Reader and only grows it when more
+// input could change the result: the first matching token's match still
+// touches the end of the buffer (more input could still extend it), or no
+// token matches the buffer yet. The token channel is closed when r is exhausted;
+// the error channel carries at most one error, sent only on failure.
+func (g *Grammar) Lex(r io.Reader) (<-chan LexToken, <-chan error) {
+	return g.lexFrom(r, Position{Offset: 0, Line: 1, Col: 1})
+}
+
+// lexFrom is Lex, but starting position bookkeeping from start instead of
+// the beginning of a file -- used by Parser.Parse's Recover mode to resume
+// lexing partway through inp (past a bad byte) while still reporting
+// positions relative to the original input rather than the resumed slice.
+func (g *Grammar) lexFrom(r io.Reader, start Position) (<-chan LexToken, <-chan error) {
+	tokens := make(chan LexToken)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(tokens)
+		defer close(errs)
+
+		lx := &lexer{grammar: g, r: bufio.NewReader(r), offset: start.Offset, line: start.Line, col: start.Col}
+		for {
+			tok, ok, err := lx.next()
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !ok {
+				return
+			}
+			tokens <- tok
+		}
+	}()
+
+	return tokens, errs
+}
+
+// drain exhausts tokens without processing it. Lex's producer goroutine
+// blocks sending to tokens (it's unbuffered) until something reads the next
+// value, so a caller that stops ranging over tokens early -- e.g. Parse
+// giving up after a non-recoverable error with input left over -- must
+// drain the rest or the goroutine leaks forever.
+func drain(tokens <-chan LexToken) {
+	for range tokens {
+	}
+}
+
+// LexAll synchronously lexes data in full and returns every token, or the
+// first error encountered. It exists as a convenience for tests and small
+// inputs that have no need for Lex's streaming behavior.
+func (g *Grammar) LexAll(data []byte) ([]LexToken, error) {
+	tokens, errs := g.Lex(bytes.NewReader(data))
+
+	var out []LexToken
+	for tok := range tokens {
+		out = append(out, tok)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// lexer holds the mutable state Lex advances as it pulls bytes from r.
+type lexer struct {
+	grammar *Grammar
+	r *bufio.Reader
+	buf []byte
+	atEOF bool
+	offset, line, col int
+}
+
+// next returns the next token, (false, nil) at end of input, or an error.
+func (lx *lexer) next() (LexToken, bool, error) {
+	for {
+		if len(lx.buf) == 0 {
+			if lx.atEOF {
+				return LexToken{}, false, nil
+			}
+			if err := lx.fill(); err != nil {
+				return LexToken{}, false, err
+			}
+			continue
+		}
+
+		text := string(lx.buf)
+		for _, token := range lx.grammar.Tokens {
+			loc := token.Shape.FindStringIndex(text)
+			if loc == nil || loc[0] != 0 {
+				continue
+			}
+			if loc[1] == len(text) && !lx.atEOF {
+				// The match reaches the edge of our lookahead -- more input
+				// could still extend it, so don't commit yet (nor fall back to
+				// a later, shorter token); read more input first.
+				break
+			}
+
+			matchText := text[:loc[1]]
+			pos := Position{Offset: lx.offset, Line: lx.line, Col: lx.col}
+			lx.advance(matchText)
+			lx.buf = lx.buf[len(matchText):]
+
+			return LexToken{Name: token.Name, Text: matchText, Pos: pos}, true, nil
+		}
+
+		if lx.atEOF {
+			return LexToken{}, false, &ParseErr{
+				Position: Position{Offset: lx.offset, Line: lx.line, Col: lx.col},
+				Message: "No valid token found!",
+			}
+		}
+
+		if err := lx.fill(); err != nil {
+			return LexToken{}, false, err
+		}
+	}
+}
+
+// fill reads another chunk from r into buf, marking atEOF once r is
+// exhausted.
+func (lx *lexer) fill() error {
+	chunk := make([]byte, lexChunkSize)
+	n, err := lx.r.Read(chunk)
+	if n > 0 {
+		lx.buf = append(lx.buf, chunk[:n]...)
+	}
+	if err != nil {
+		lx.atEOF = true
+		if err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
+
+// advance moves the lexer's offset/line/col counters past text.
+func (lx *lexer) advance(text string) {
+	for i := 0; i < len(text); i++ {
+		lx.offset++
+		if text[i] == '\n' {
+			lx.line++
+			lx.col = 1
+		} else {
+			lx.col++
+		}
+	}
+}
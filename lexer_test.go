@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// Covers chunk0-4's core contract: LexAll streams every token in order and
+// surfaces no error for well-formed input.
+func TestLexAllProducesExpectedTokens(t *testing.T) {
+	toks, err := DefaultGrammar().LexAll([]byte(`foo(1)`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []TokenName
+	for _, tok := range toks {
+		names = append(names, tok.Name)
+	}
+
+	want := []TokenName{IDENTIFIER, CALL_IN, INTEGER_LITERAL, CALL_OUT}
+	if len(names) != len(want) {
+		t.Fatalf("got %v tokens, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("token %d = %s, want %s (all: %v)", i, names[i], want[i], names)
+		}
+	}
+}
+
+// A token whose text is longer than lexChunkSize forces the lexer's
+// lookahead buffer to grow across more than one fill, since the candidate
+// match keeps touching the edge of the buffer. Confirm it's still lexed as
+// one token, not split at the chunk boundary.
+func TestLexTokenSpanningChunkBoundary(t *testing.T) {
+	name := strings.Repeat("a", lexChunkSize*2+17)
+
+	toks, err := DefaultGrammar().LexAll([]byte(name))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(toks) != 1 {
+		t.Fatalf("expected exactly 1 token, got %d", len(toks))
+	}
+	if toks[0].Name != IDENTIFIER || toks[0].Text != name {
+		t.Fatalf("token = %+v, want a single IDENTIFIER spanning the whole input", toks[0])
+	}
+}
+
+// Covers the error path: an unrecognized character surfaces as an error
+// rather than being silently skipped or matched.
+func TestLexAllReturnsErrorOnUnrecognizedCharacter(t *testing.T) {
+	_, err := DefaultGrammar().LexAll([]byte(`foo(@)`))
+	if err == nil {
+		t.Fatal("expected an error for the unrecognized '@' character")
+	}
+}
+
+// Regression test: Lex's producer goroutine sends on an unbuffered channel,
+// so a caller that reads some tokens and then stops (e.g. Parse aborting
+// after a non-recoverable error with input left over) must drain the rest
+// or the goroutine blocks forever. Confirm a caller using drain this way
+// doesn't leak goroutines across repeated parses of input with trailing
+// content after an error.
+func TestParseAbortingEarlyDoesNotLeakGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		if _, err := Parse(`foo(1,,2) bar(1) baz(2)`); err == nil {
+			t.Fatal("expected a parse error")
+		}
+	}
+
+	// Goroutines from the lexer's producer goroutines may still be winding
+	// down; give the runtime a chance to schedule them before comparing.
+	runtime.Gosched()
+
+	after := runtime.NumGoroutine()
+	if after > before+2 {
+		t.Fatalf("goroutine count grew from %d to %d across 20 parses; producer goroutines may be leaking", before, after)
+	}
+}
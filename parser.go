@@ -1,11 +1,14 @@
-package main
+// Package parser implements a small, pluggable recursive-descent parser for
+// an s-expression-ish call/block/arg-list grammar, producing an AstFrame
+// tree that can be walked, serialized to JSON, and round-tripped back.
+package parser
 
 import (
 	"fmt"
 	"regexp"
-	"errors"
 	"strings"
 	"strconv"
+	"unicode/utf8"
 )
 
 
@@ -42,13 +45,16 @@ type Token struct {
 	Data []string
 
 	// Called before a new ast frame is created
-	HookPreNew func([]string, *AstFrame, string, int) (map[string]interface{}, *AstFrame, error)
+	HookPreNew func([]string, *AstFrame, *Source, Position) (map[string]interface{}, *AstFrame, error)
 	// Called after a new ast frame is created
-	HookPostNew func([]string, *AstFrame, string, int) (*AstFrame, error)
+	HookPostNew func([]string, *AstFrame, *Source, Position) (*AstFrame, error)
 }
 var EMPTY_DATA map[string]interface{} = nil
 
-var TOKENS = []Token{
+// builtinTokens is the call/block/arg-list grammar this package shipped
+// with before grammars became pluggable. DefaultGrammar() registers these
+// on a fresh Grammar so existing callers of Parser keep working unchanged.
+var builtinTokens = []Token{
 
 
 	Token{
@@ -68,24 +74,22 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			// Figure out the name of the thing that is being called.
 			var callee *AstFrame
 			if len(ast.Children) > 0 {
 				callee = ast.Children[len(ast.Children)-1]
 
-				// Remove the callee from the list of children.
-				if len(ast.Children) == 1 {
-					ast.Children = make([]*AstFrame, 0)
-				} else {
-					ast.Children = ast.Children[:1]
-				}
+				// Remove the callee from the list of children, keeping
+				// everything before it (e.g. an earlier sibling statement
+				// in the same block) intact.
+				ast.Children = ast.Children[:len(ast.Children)-1]
 			} else {
 				// Can't find a callee!
 				return nil, ast, ParseError(
-					inp, pointer,
+					src, pos,
 					"No callee identifier found before leading parenthesis in call expression.",
 				)
 			}
@@ -94,6 +98,9 @@ var TOKENS = []Token{
 				Name: CALL_EXPRESSION,
 				Data: map[string]interface{}{"Callee": callee},
 				Parent: ast,
+				Pos: callee.Pos,
+				Line: callee.Line,
+				Col: callee.Col,
 			}
 
 			// Add new frame to existing AST
@@ -110,36 +117,19 @@ var TOKENS = []Token{
 		HookPostNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (*AstFrame, error) {
-			var data []*AstFrame
-
-			lastItemWasAnItemSeperator := true
-			for index, child := range ast.Children {
-				if child.Name == WHITESPACE { continue }
-				if child.Name == ITEM_SEPERATOR {
-					if lastItemWasAnItemSeperator {
-						return ast, ParseError(
-							inp, pointer,
-							fmt.Sprintf("Two item seperators were found in a row!"),
-						)
-					} else {
-						continue
-					}
-				}
-				if index == 0 && child.Name == CALL_IN { continue }
-				if index == len(ast.Children) - 1 && child.Name == CALL_OUT { continue }
-
-				if child.Name == ITEM_SEPERATOR {
-					lastItemWasAnItemSeperator = true
-				} else {
-					lastItemWasAnItemSeperator = false
-					data = append(data, child)
-				}
+			data, err := foldArguments(ast, ast.Root().grammar, src, pos, CALL_IN, CALL_OUT)
+			if err != nil {
+				return ast, err
 			}
 
-			ast.Data = map[string]interface{}{ "Arguments": data }
+			if ast.Data == nil {
+				ast.Data = map[string]interface{}{}
+			}
+			ast.Data["Arguments"] = data
+			ast.EndPos = pos.Offset + len(match[0])
 			return ast.Parent, nil
 		},
 	},
@@ -152,13 +142,16 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			newFrame := &AstFrame{
 				Name: BLOCK_EXPRESSION,
 				Data: EMPTY_DATA,
 				Parent: ast,
+				Pos: pos.Offset,
+				Line: pos.Line,
+				Col: pos.Col,
 			}
 
 			// Add new frame to existing AST
@@ -171,13 +164,26 @@ var TOKENS = []Token{
 		Name: BLOCK_OUT,
 		Shape: regexp.MustCompile(`^end`),
 
-		// Move to the previous stack frame.
+		// Move to the previous stack frame, folding the block's body the
+		// same way a call's arguments are folded, so e.g. a bare `1+2`
+		// inside a `do...end` becomes one BINARY_EXPRESSION instead of
+		// being left as three flat sibling tokens.
 		HookPostNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (*AstFrame, error) {
+			data, err := foldArguments(ast, ast.Root().grammar, src, pos, BLOCK_IN, BLOCK_OUT)
+			if err != nil {
+				return ast, err
+			}
+
+			if ast.Data == nil {
+				ast.Data = map[string]interface{}{}
+			}
+			ast.Data["Statements"] = data
+			ast.EndPos = pos.Offset + len(match[0])
 			return ast.Parent, nil
 		},
 	},
@@ -190,13 +196,16 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			newFrame := &AstFrame{
 				Name: ARG_LIST_EXPRESSION,
 				Data: EMPTY_DATA,
 				Parent: ast,
+				Pos: pos.Offset,
+				Line: pos.Line,
+				Col: pos.Col,
 			}
 
 			// Add new frame to existing AST
@@ -213,36 +222,19 @@ var TOKENS = []Token{
 		HookPostNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (*AstFrame, error) {
-			var data []*AstFrame
-
-			lastItemWasAnItemSeperator := true
-			for index, child := range ast.Children {
-				if child.Name == WHITESPACE { continue }
-				if child.Name == ITEM_SEPERATOR {
-					if lastItemWasAnItemSeperator {
-						return ast, ParseError(
-							inp, pointer,
-							fmt.Sprintf("Two item seperators were found in a row!"),
-						)
-					} else {
-						continue
-					}
-				}
-				if index == 0 && child.Name == ARG_LIST_IN { continue }
-				if index == len(ast.Children) - 1 && child.Name == ARG_LIST_OUT { continue }
-
-				if child.Name == ITEM_SEPERATOR {
-					lastItemWasAnItemSeperator = true
-				} else {
-					lastItemWasAnItemSeperator = false
-					data = append(data, child)
-				}
+			data, err := foldArguments(ast, ast.Root().grammar, src, pos, ARG_LIST_IN, ARG_LIST_OUT)
+			if err != nil {
+				return ast, err
 			}
 
-			ast.Data = map[string]interface{}{ "Arguments": data }
+			if ast.Data == nil {
+				ast.Data = map[string]interface{}{}
+			}
+			ast.Data["Arguments"] = data
+			ast.EndPos = pos.Offset + len(match[0])
 			return ast.Parent, nil
 		},
 	},
@@ -257,8 +249,8 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			return map[string]interface{}{ "Content": match[1] }, ast, nil
 		},
@@ -271,14 +263,14 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			float, err := strconv.ParseFloat(strings.Replace(match[0], "_", "", -1), 64)
 
 			if err != nil {
 				return EMPTY_DATA, ast, ParseError(
-					inp, pointer,
+					src, pos,
 					"Error parsing float from source: "+err.Error(),
 				)
 			}
@@ -294,14 +286,14 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			integer, err := strconv.Atoi(strings.Replace(match[0], "_", "", -1))
 
 			if err != nil {
 				return EMPTY_DATA, ast, ParseError(
-					inp, pointer,
+					src, pos,
 					"Error parsing integer from source: "+err.Error(),
 				)
 			}
@@ -318,8 +310,8 @@ var TOKENS = []Token{
 		HookPreNew: func(
 			match []string,
 			ast *AstFrame,
-			inp string,
-			pointer int,
+			src *Source,
+			pos Position,
 		) (map[string]interface{}, *AstFrame, error) {
 			return map[string]interface{}{ "Name": match[0] }, ast, nil
 		},
@@ -333,107 +325,339 @@ type AstFrame struct {
 	Parent *AstFrame
 
 	Data map[string]interface{}
+
+	// Pos, Line and Col are the byte offset and 1-indexed line/column this
+	// frame's leading token begins at. EndPos is the byte offset one past
+	// the frame's last byte: the end of a token frame's own text, of a
+	// CALL_EXPRESSION/BLOCK_EXPRESSION/ARG_LIST_EXPRESSION's closing token
+	// (or the end of input, if that closer was synthesized), of a binary or
+	// prefix expression's last operand, or of the whole input for ROOT. A
+	// call/block/arg-list whose closing token failed to fold keeps it zero.
+	Pos int
+	Line int
+	Col int
+	EndPos int
+
+	// source holds the Source the tree was parsed from, and grammar the
+	// Grammar it was parsed with. Only the root frame carries non-nil
+	// values; descendants look them up via Root().
+	source *Source
+	grammar *Grammar
 }
 
-// When an erro happens in the parse step, print out a pretty error, like the below:
-// parse error on line 1: No valid token found!
-// foo('bar')
-//     ^
-func ParseError(inp string, pointer int, err string) error {
-	lines := strings.Split(inp, "\n")
-
-	// Get the end of the parsed line by finding the next newline.
-	endOfLineIndex := pointer + strings.Index(inp[pointer:], "\n")
-	if endOfLineIndex == pointer + (-1) {
-		endOfLineIndex = len(inp)
+// Root walks up the Parent chain and returns the top-most AstFrame.
+func (f *AstFrame) Root() *AstFrame {
+	frame := f
+	for frame.Parent != nil {
+		frame = frame.Parent
 	}
+	return frame
+}
 
-	// Get the start of the parsed line by finding the previous newline.
-	beginningOfLineIndex := pointer - strings.LastIndex(inp[:pointer], "\n")
-	if beginningOfLineIndex == pointer - (-1) {
-		beginningOfLineIndex = 0
-	}
+// LineCol returns the 1-indexed line and column this frame begins at.
+func (f *AstFrame) LineCol() (line int, col int) {
+	return f.Line, f.Col
+}
 
-	// Get the contents of the line
-	currentLine := inp[beginningOfLineIndex:endOfLineIndex]
-	numberOfSpacesToPointer := pointer - beginningOfLineIndex
+// ParseError renders a syntax error in the style of goawk's ParseError:
+// "parse error at L:C: <message>", followed by the offending line and a
+// caret pointing at the column.
+func ParseError(src *Source, pos Position, err string) error {
+	return &ParseErr{Position: pos, Source: src, Message: err}
+}
 
-	// Calculate the line number now that we know the line that the error was related to.
-	var lineNumber int
-	for i := 0; i < len(lines); i++ {
-		if lines[i] == currentLine {
-			lineNumber = i+1
-			break
-		}
+// ParseErr is the concrete error type returned by ParseError. It is exposed
+// so callers (and ErrorList, used by the parser's error-recovery mode) can
+// inspect the Position a failure occurred at instead of just its message.
+type ParseErr struct {
+	Position Position
+	Source *Source
+	Message string
+}
+
+func (e *ParseErr) Error() string {
+	line := ""
+	if e.Source != nil {
+		line = e.Source.Line(e.Position.Line)
 	}
 
-	// Pad left the indicator for which the pointer points to.
-	spaces := ""
-	for i := 0; i < numberOfSpacesToPointer; i++ { spaces += " " }
+	spaces := strings.Repeat(" ", e.Position.Col-1)
 
-	// Return a formatted error
-	return errors.New(fmt.Sprintf(
-		"parse error on line %d: %s\n%s\n%s^",
-		lineNumber,
-		err,
-		currentLine,
+	return fmt.Sprintf(
+		"parse error at %d:%d: %s\n%s\n%s^",
+		e.Position.Line,
+		e.Position.Col,
+		e.Message,
+		line,
 		spaces,
-	))
+	)
+}
+
+// Parse parses source with the default, built-in call/block/arg-list
+// grammar. It is a convenience wrapper around NewParser(DefaultGrammar());
+// use NewParser directly to parse with a custom Grammar.
+//
+// BREAKING CHANGE: this is what used to be the package-level func
+// Parser(string) (*AstFrame, error). Making the grammar configurable
+// required a Parser type to hold it (NewParser(g *Grammar) *Parser below),
+// and Go doesn't allow a function and a type to share one identifier in the
+// same package, so the free function had to be renamed rather than kept
+// alongside it. Callers of the old parser.Parser(inp) need to switch to
+// parser.Parse(inp) (equivalent) or NewParser(g).Parse(inp) (configurable).
+func Parse(inp string) (*AstFrame, error) {
+	return NewParser(DefaultGrammar()).Parse(inp)
 }
 
-func Parser(inp string) (*AstFrame, error) {
-	var ast AstFrame = AstFrame{Name: ROOT, Parent: nil}
+// Parser turns source text into an AstFrame tree, driven by a Grammar. It
+// replaces the free func Parser(string) (*AstFrame, error) this package
+// used to export -- see the BREAKING CHANGE note on Parse.
+type Parser struct {
+	Grammar *Grammar
+
+	// Config is the zero value by default, which parses exactly as Parser
+	// always has. Set Config.Recover to switch on best-effort parsing; see
+	// ParserConfig.
+	Config ParserConfig
+}
+
+// NewParser constructs a Parser that parses according to g.
+func NewParser(g *Grammar) *Parser {
+	return &Parser{Grammar: g}
+}
+
+// Parse runs p's Grammar over inp and returns the resulting AST. It lexes
+// inp with Grammar.Lex and drives each Token's hooks off that stream, so
+// the actual scanning happens in one place shared with Lex/LexAll.
+//
+// With the default ParserConfig, Parse aborts and returns the first error
+// it hits. If p.Config.Recover is true, it instead records the error,
+// skips forward to the next synchronization token (see isSyncToken) and
+// keeps going, so a best-effort AST comes back instead of nothing -- any
+// call/block/arg-list still open at EOF gets a synthesized closer, marked
+// with Data["Synthetic"]=true. A lexer-level error (an unrecognized
+// character, e.g. a stray byte left by a mid-edit file) is recovered from
+// the same way: lexing resumes one rune past the offending byte instead of
+// giving up on the rest of the input. Every error collected this way is
+// returned together as an ErrorList.
+func (p *Parser) Parse(inp string) (*AstFrame, error) {
+	src := NewSource(inp)
+
+	var ast AstFrame = AstFrame{Name: ROOT, Parent: nil, source: src, grammar: p.Grammar}
 	var currentFrame *AstFrame = &ast
 
-	// Used when running hooks. Defined once and reused for each invocation.
-	var err error
-
-	// Contains the current index in `inp`
-	pointer := 0
-
-	// While items can be pulled off the front of the input...
-	outer:
-	for pointer < len(inp) {
-		// Try to find a token that matches.
-		for _, token := range TOKENS {
-			if match := token.Shape.FindStringSubmatch(inp[pointer:]); len(match) > 0 {
-				// Call the pre token hook to hopefully get the contents of the data to put intide
-				// that ast frame.
-				data := EMPTY_DATA
-				if token.HookPreNew != nil {
-					data, currentFrame, err = token.HookPreNew(match, currentFrame, inp, pointer)
-					if err != nil { return nil, err }
+	byName := make(map[TokenName]Token, len(p.Grammar.Tokens))
+	for _, token := range p.Grammar.Tokens {
+		byName[token.Name] = token
+	}
+
+	var errList ErrorList
+
+	// toErr normalizes err to a *ParseErr, attaching src if it doesn't
+	// already carry one (every error this package raises is already a
+	// *ParseErr, so the fallback only matters for hypothetical future
+	// callers of a custom Grammar's hooks).
+	toErr := func(err error) *ParseErr {
+		pe, ok := err.(*ParseErr)
+		if !ok {
+			pe = &ParseErr{Position: src.Position(len(inp)), Message: err.Error()}
+		}
+		if pe.Source == nil {
+			pe.Source = src
+		}
+		return pe
+	}
+
+	// process drives a single lexed token through its hooks. It's pulled
+	// out of the main loop below so Recover mode's skip-forward can run it
+	// on the synchronization token it lands on too.
+	process := func(lexTok LexToken) error {
+		token := byName[lexTok.Name]
+		pos := lexTok.Pos
+
+		// Hooks expect regexp submatches (e.g. STRING_LITERAL wants the
+		// quoted content); re-derive them from the already-matched text,
+		// which is cheap since it's just this one token, not the tail of
+		// the whole input.
+		match := token.Shape.FindStringSubmatch(lexTok.Text)
+
+		// Call the pre token hook to hopefully get the contents of the data to put intide
+		// that ast frame.
+		data := EMPTY_DATA
+		var err error
+		if token.HookPreNew != nil {
+			data, currentFrame, err = token.HookPreNew(match, currentFrame, src, pos)
+			if err != nil { return err }
+		}
+
+		// Add matching tokens to the token list, and clear the accumulator so we can find
+		// the next token.
+		currentFrame.Children = append(currentFrame.Children, &AstFrame{
+			Name: token.Name,
+			Data: data,
+			Parent: currentFrame,
+			Pos: pos.Offset,
+			Line: pos.Line,
+			Col: pos.Col,
+			EndPos: pos.Offset + len(lexTok.Text),
+		})
+
+		// Call the post token hook, which returns the frame parsing continues
+		// in (e.g. the parent frame once a closing token is seen).
+		if token.HookPostNew != nil {
+			currentFrame, err = token.HookPostNew(match, currentFrame, src, pos)
+			if err != nil { return err }
+		}
+
+		return nil
+	}
+
+	// lexPos/remaining track where the next lexer run should pick up: the
+	// start, unless a lexer-level error (see below) forces a restart
+	// partway through inp.
+	lexPos := Position{Offset: 0, Line: 1, Col: 1}
+	remaining := inp
+
+relex:
+	for {
+		tokens, errs := p.Grammar.lexFrom(strings.NewReader(remaining), lexPos)
+
+	tokenLoop:
+		for lexTok := range tokens {
+			if err := process(lexTok); err != nil {
+				pe := toErr(err)
+				errList = append(errList, pe)
+
+				if !p.Config.Recover {
+					drain(tokens)
+					return nil, pe
+				}
+				if p.Config.MaxErrors > 0 && len(errList) >= p.Config.MaxErrors {
+					drain(tokens)
+					return nil, errList
 				}
 
-				// Add matching tokens to the token list, and clear the accumulator so we can find
-				// the next token.
-				currentFrame.Children = append(currentFrame.Children, &AstFrame{
-					Name: token.Name,
-					Data: data,
-					Parent: currentFrame,
-				})
-
-				// Call the pre token hook to hopefully get the contents of the data to put intide
-				// that ast frame.
-				if token.HookPostNew != nil {
-					currentFrame, err = token.HookPostNew(match, currentFrame, inp, pointer)
-					if err != nil { return nil, err }
+				if isSyncToken(lexTok) {
+					// The token that failed was itself a synchronization point
+					// (a CALL_OUT/ARG_LIST_OUT whose argument folding errored)
+					// -- recover right here by abandoning this frame's data and
+					// popping back to its parent like a successful close would,
+					// instead of scanning further ahead and swallowing whatever
+					// comes next.
+					currentFrame = currentFrame.Parent
+					continue
 				}
 
-				pointer += len(match[0])
-				continue outer;
+				// Otherwise skip forward to the next synchronization token,
+				// discarding everything in between, then process it normally
+				// -- e.g. a CALL_OUT found this way still closes its frame
+				// like it always would.
+				for sync := range tokens {
+					if !isSyncToken(sync) {
+						continue
+					}
+					if err := process(sync); err != nil {
+						errList = append(errList, toErr(err))
+						if p.Config.MaxErrors > 0 && len(errList) >= p.Config.MaxErrors {
+							drain(tokens)
+							return nil, errList
+						}
+						currentFrame = currentFrame.Parent
+						continue tokenLoop
+					}
+					continue tokenLoop
+				}
+				break tokenLoop
 			}
 		}
 
-		// Found no token!
-		return nil, ParseError(inp, pointer, "No valid token found!")
+		lexErr := <-errs
+		if lexErr == nil {
+			break relex
+		}
+
+		pe := toErr(lexErr)
+		if !p.Config.Recover {
+			return nil, pe
+		}
+		errList = append(errList, pe)
+		if p.Config.MaxErrors > 0 && len(errList) >= p.Config.MaxErrors {
+			return nil, errList
+		}
+
+		// A lexer-level error means Lex hit a byte that no token's Shape
+		// matched -- e.g. a stray character left by a mid-edit file -- and
+		// its goroutine has already exited, so there's no channel left to
+		// resume reading from. Recover the same way isSyncToken's skip-
+		// forward does for a syntax error: keep whatever was already
+		// parsed, and start a fresh lex one rune past the bad byte instead
+		// of losing the rest of a well-formed file to one bad character.
+		skipTo := pe.Position.Offset
+		if skipTo < len(inp) {
+			_, size := utf8.DecodeRuneInString(inp[skipTo:])
+			if size == 0 {
+				size = 1
+			}
+			skipTo += size
+		}
+		if skipTo >= len(inp) {
+			break relex
+		}
+
+		remaining = inp[skipTo:]
+		lexPos = src.Position(skipTo)
 	}
 
-	// Make sure that on parsing completion, we're back at the root ast node.
-	if currentFrame != &ast {
-		return nil, ParseError(inp, pointer, "When parsing, finished in a frame deeper than the top frame.")
+	// A frame still open at EOF means some call/block/arg-list never saw
+	// its closing token. Outside Recover mode that's fatal, same as always;
+	// inside it, synthesize the missing closer so editor integrations
+	// still get a complete-shaped tree out of incomplete source.
+	for currentFrame != &ast {
+		if !p.Config.Recover {
+			return nil, ParseError(src, src.Position(len(inp)), "When parsing, finished in a frame deeper than the top frame.")
+		}
+
+		unterminated := currentFrame
+		currentFrame = currentFrame.Parent
+
+		errList = append(errList, toErr(ParseError(
+			src, src.Position(len(inp)),
+			fmt.Sprintf("%s was never closed before the end of input.", unterminated.Name),
+		)))
+		if p.Config.MaxErrors > 0 && len(errList) >= p.Config.MaxErrors {
+			return nil, errList
+		}
+		if err := closeUnterminatedFrame(unterminated, src, len(inp)); err != nil {
+			errList = append(errList, toErr(err))
+			if p.Config.MaxErrors > 0 && len(errList) >= p.Config.MaxErrors {
+				return nil, errList
+			}
+		}
 	}
 
+	ast.EndPos = len(inp)
+
+	// Fold the top-level body the same way a call/arg-list/block's body is
+	// folded, so e.g. a bare "1+2" becomes one BINARY_EXPRESSION instead of
+	// three flat ROOT children. There's no delimiter token to skip here, so
+	// inName/outName never match anything.
+	if statements, err := foldArguments(&ast, p.Grammar, src, src.Position(len(inp)), "", ""); err != nil {
+		if !p.Config.Recover {
+			return nil, toErr(err)
+		}
+		errList = append(errList, toErr(err))
+		if p.Config.MaxErrors > 0 && len(errList) >= p.Config.MaxErrors {
+			return nil, errList
+		}
+	} else {
+		if ast.Data == nil {
+			ast.Data = map[string]interface{}{}
+		}
+		ast.Data["Statements"] = statements
+	}
+
+	if len(errList) > 0 {
+		return &ast, errList
+	}
 	return &ast, nil
 }
 
@@ -449,23 +673,3 @@ func PrintAst(ast *AstFrame, indentation string) {
 		PrintAst(child, indentation+"  ")
 	}
 }
-
-func main() {
-	// data := `
-	// func(my_func <a b c> do
-	// 	foo("bar", 123.456)
-	// end)
-	// `
-	data := `do
-		func(a<b> do 1 end)
-		foo()
-	end`
-	// data := "foo(\"bar\")"
-	ast, err := Parser(data)
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("Ast:")
-		PrintAst(ast, "")
-	}
-}
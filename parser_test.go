@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Regression test for a bug in CALL_IN's HookPreNew: it used to truncate
+// the enclosing frame's Children down to just its first element while
+// popping off the callee, rather than just removing the callee, silently
+// dropping every earlier sibling statement whenever a second call followed
+// another in the same block. This is the exact shape of the shipped demo
+// (cmd/goparser/main.go), which printed a tree missing its first statement
+// for every commit up to this fix.
+func TestParsePreservesEarlierCallsInTheSameBlock(t *testing.T) {
+	ast, err := Parse(`do
+		bar(1)
+		foo(2, 3)
+	end`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	block := ast.Children[0]
+
+	var names []string
+	for _, child := range block.Children {
+		if child.Name != CALL_EXPRESSION {
+			continue
+		}
+		name, ok := child.Callee().StringValue()
+		if !ok {
+			t.Fatalf("call has no callee name: %+v", child)
+		}
+		names = append(names, name)
+	}
+
+	if len(names) != 2 || names[0] != "bar" || names[1] != "foo" {
+		t.Fatalf("expected both bar and foo calls present in order, got %v", names)
+	}
+}
+
+// Covers chunk0-1's own feature: a tree encoded via ToJSONObject, sent
+// through actual encoding/json (as a real caller persisting or transmitting
+// it would), and decoded back via ASTFromJSONObject should compare equal to
+// the original via Equals.
+func TestParseThenJSONRoundTripIsEqual(t *testing.T) {
+	ast, err := Parse(`do
+		func(a<b> do 1 end)
+		foo("bar", 123.456)
+	end`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	encoded, err := json.Marshal(ast.ToJSONObject())
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	roundTripped, err := ASTFromJSONObject(decoded)
+	if err != nil {
+		t.Fatalf("ASTFromJSONObject: %v", err)
+	}
+
+	if ok, reason := ast.Equals(roundTripped, false); !ok {
+		t.Fatalf("round-tripped tree diverged: %s", reason)
+	}
+}
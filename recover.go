@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParserConfig controls optional Parser behavior. Its zero value parses
+// exactly as Parser always has: the first error aborts immediately.
+type ParserConfig struct {
+	// Recover, when true, makes Parse skip to the next synchronization
+	// token after a syntax error (see isSyncToken) and keep parsing instead
+	// of aborting, so a best-effort AST comes back even from source with
+	// errors in it -- the same trade-off go/parser makes for editor
+	// integrations that need *something* out of incomplete code.
+	Recover bool
+
+	// MaxErrors caps how many errors Recover mode will collect before
+	// giving up early. Zero (the default) means no cap.
+	MaxErrors int
+}
+
+// ErrorList is every error a Recover-mode parse accumulated, returned
+// together as Parse's error whenever it collected at least one.
+type ErrorList []*ParseErr
+
+func (errs ErrorList) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d parse errors:\n", len(errs))
+	for _, err := range errs {
+		fmt.Fprintln(&b, err.Error())
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// isSyncToken reports whether tok is a point Recover mode can resume
+// parsing at after skipping forward past a syntax error: the close of a
+// call/block/arg-list, an item separator, or a line break.
+func isSyncToken(tok LexToken) bool {
+	switch tok.Name {
+	case ITEM_SEPERATOR, CALL_OUT, BLOCK_OUT, ARG_LIST_OUT:
+		return true
+	case WHITESPACE:
+		return strings.Contains(tok.Text, "\n")
+	default:
+		return false
+	}
+}
+
+// closeUnterminatedFrame synthesizes the closing token frame never got
+// because the input ran out first, running whatever bookkeeping that token's
+// hook would normally have done -- e.g. folding a CALL_EXPRESSION's children
+// into its Arguments -- so its folded Data reads the same as a properly
+// closed frame's. No closing token frame is added to its Children; the frame
+// is marked Synthetic and its EndPos is the end of input.
+func closeUnterminatedFrame(frame *AstFrame, src *Source, endOffset int) error {
+	pos := src.Position(endOffset)
+
+	switch frame.Name {
+	case CALL_EXPRESSION:
+		data, err := foldArguments(frame, frame.Root().grammar, src, pos, CALL_IN, CALL_OUT)
+		if err != nil {
+			return err
+		}
+		if frame.Data == nil {
+			frame.Data = map[string]interface{}{}
+		}
+		frame.Data["Arguments"] = data
+
+	case ARG_LIST_EXPRESSION:
+		data, err := foldArguments(frame, frame.Root().grammar, src, pos, ARG_LIST_IN, ARG_LIST_OUT)
+		if err != nil {
+			return err
+		}
+		if frame.Data == nil {
+			frame.Data = map[string]interface{}{}
+		}
+		frame.Data["Arguments"] = data
+
+	case BLOCK_EXPRESSION:
+		data, err := foldArguments(frame, frame.Root().grammar, src, pos, BLOCK_IN, BLOCK_OUT)
+		if err != nil {
+			return err
+		}
+		if frame.Data == nil {
+			frame.Data = map[string]interface{}{}
+		}
+		frame.Data["Statements"] = data
+	}
+
+	if frame.Data == nil {
+		frame.Data = map[string]interface{}{}
+	}
+	frame.Data["Synthetic"] = true
+	frame.EndPos = endOffset
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package parser
+
+import "testing"
+
+// Covers chunk0-6's core contract: without Recover, the first syntax error
+// aborts the parse and returns a single *ParseErr.
+func TestParseWithoutRecoverAbortsOnFirstError(t *testing.T) {
+	_, err := Parse(`foo(1) bar(`)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, ok := err.(*ParseErr); !ok {
+		t.Fatalf("expected a *ParseErr, got %T", err)
+	}
+}
+
+// With Recover, a syntax error is recorded and parsing skips forward to the
+// next synchronization token (see isSyncToken) instead of aborting, so a
+// well-formed statement after the bad one still makes it into the tree. A
+// CALL_OUT whose argument list has two item separators in a row is itself a
+// synchronization token (isSyncToken(CALL_OUT) is true), so recovery closes
+// that frame in place rather than scanning further ahead.
+func TestRecoverSkipsToNextSyncTokenAfterASyntaxError(t *testing.T) {
+	p := NewParser(DefaultGrammar())
+	p.Config.Recover = true
+
+	ast, err := p.Parse(`foo(1,,2) bar(3)`)
+	if err == nil {
+		t.Fatal("expected errors to be recorded even though parsing recovered")
+	}
+	if _, ok := err.(ErrorList); !ok {
+		t.Fatalf("expected an ErrorList, got %T", err)
+	}
+
+	statements := ast.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected both calls to survive recovery, got %d statements: %+v", len(statements), statements)
+	}
+
+	name, ok := statements[1].Callee().StringValue()
+	if !ok || name != "bar" {
+		t.Fatalf("expected the second call to be bar(...), got %+v", statements[1])
+	}
+}
+
+// MaxErrors caps how many errors Recover collects before giving up, even
+// though more input (and more errors) remains.
+func TestRecoverStopsAtMaxErrors(t *testing.T) {
+	p := NewParser(DefaultGrammar())
+	p.Config.Recover = true
+	p.Config.MaxErrors = 1
+
+	_, err := p.Parse(`foo(1,,2) bar(3,,4) baz(5,,6)`)
+	errs, ok := err.(ErrorList)
+	if !ok {
+		t.Fatalf("expected an ErrorList, got %T", err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("expected MaxErrors to cap collection at 1 error, got %d", len(errs))
+	}
+}
+
+// A call left open at EOF gets a synthesized CALL_OUT, folding its
+// Arguments the same way a real CALL_OUT would, and is marked Synthetic.
+func TestRecoverSynthesizesUnterminatedCall(t *testing.T) {
+	p := NewParser(DefaultGrammar())
+	p.Config.Recover = true
+
+	ast, err := p.Parse(`foo(1, 2`)
+	if err == nil {
+		t.Fatal("expected an error recording the unterminated call")
+	}
+
+	call := ast.Statements()[0]
+	if call.Name != CALL_EXPRESSION {
+		t.Fatalf("expected a CALL_EXPRESSION, got %s", call.Name)
+	}
+	if synthetic, _ := call.Data["Synthetic"].(bool); !synthetic {
+		t.Fatal("expected the unterminated call to be marked Synthetic")
+	}
+	if len(call.Arguments()) != 2 {
+		t.Fatalf("expected both arguments to have been folded despite the missing CALL_OUT, got %d", len(call.Arguments()))
+	}
+}
+
+// A block left open at EOF gets its Statements folded the same way a real
+// BLOCK_OUT would, not left nil -- otherwise Walk/Rewrite silently drop the
+// whole block body, since semanticChildren reads Statements() for a
+// BLOCK_EXPRESSION, not Children.
+func TestRecoverSynthesizesUnterminatedBlockStatements(t *testing.T) {
+	p := NewParser(DefaultGrammar())
+	p.Config.Recover = true
+
+	ast, err := p.Parse("do\nfoo()\nbar()")
+	if err == nil {
+		t.Fatal("expected an error recording the unterminated block")
+	}
+
+	block := ast.Statements()[0]
+	if block.Name != BLOCK_EXPRESSION {
+		t.Fatalf("expected a BLOCK_EXPRESSION, got %s", block.Name)
+	}
+	if synthetic, _ := block.Data["Synthetic"].(bool); !synthetic {
+		t.Fatal("expected the unterminated block to be marked Synthetic")
+	}
+
+	statements := block.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected both calls to be folded into Statements, got %d: %+v", len(statements), statements)
+	}
+}
+
+// A lexer-level error (a byte no token's Shape matches) recovers the same
+// way a syntax error does: the bad byte is recorded as an error, and
+// well-formed statements after it still make it into the tree, instead of
+// the whole rest of the input being discarded.
+func TestRecoverResynchronizesPastALexerError(t *testing.T) {
+	p := NewParser(DefaultGrammar())
+	p.Config.Recover = true
+
+	ast, err := p.Parse("foo(1)\n@\nbar(2)")
+	if err == nil {
+		t.Fatal("expected the lexer error to be recorded")
+	}
+
+	statements := ast.Statements()
+	if len(statements) != 2 {
+		t.Fatalf("expected both calls to survive around the bad byte, got %d: %+v", len(statements), statements)
+	}
+
+	var names []string
+	for _, call := range statements {
+		name, ok := call.Callee().StringValue()
+		if !ok {
+			t.Fatalf("expected a callee on %+v", call)
+		}
+		names = append(names, name)
+	}
+	if names[0] != "foo" || names[1] != "bar" {
+		t.Fatalf("expected foo then bar, got %v", names)
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import "sort"
+
+// Position identifies a single offset into a Source, pre-resolved to its
+// line and column so callers never need to re-scan the input to print it.
+type Position struct {
+	Offset int
+	Line int
+	Col int
+}
+
+// Source wraps an input string together with a table of newline offsets, so
+// any byte offset can be converted to a Position in O(log n) instead of
+// re-scanning the input from the start every time. This is the same idea as
+// go/token.FileSet, scoped down to a single file.
+type Source struct {
+	Text string
+	lineOffsets []int
+}
+
+// NewSource builds a Source for text, recording the offset each line begins
+// at once up front.
+func NewSource(text string) *Source {
+	lineOffsets := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+
+	return &Source{Text: text, lineOffsets: lineOffsets}
+}
+
+// Position resolves offset to a Position, binary searching the newline
+// table for the line it falls on.
+func (s *Source) Position(offset int) Position {
+	if offset > len(s.Text) {
+		offset = len(s.Text)
+	}
+
+	// Find the last line whose start offset is <= offset.
+	line := sort.Search(len(s.lineOffsets), func(i int) bool {
+		return s.lineOffsets[i] > offset
+	}) - 1
+	if line < 0 {
+		line = 0
+	}
+
+	return Position{
+		Offset: offset,
+		Line: line + 1,
+		Col: offset - s.lineOffsets[line] + 1,
+	}
+}
+
+// Line returns the text of the given 1-indexed line, with no trailing
+// newline.
+func (s *Source) Line(n int) string {
+	if n < 1 || n > len(s.lineOffsets) {
+		return ""
+	}
+
+	start := s.lineOffsets[n-1]
+
+	end := len(s.Text)
+	if n < len(s.lineOffsets) {
+		end = s.lineOffsets[n] - 1
+	}
+	if end > 0 && end <= len(s.Text) && s.Text[end-1] == '\r' {
+		end--
+	}
+
+	return s.Text[start:end]
+}
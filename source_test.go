@@ -0,0 +1,67 @@
+package parser
+
+import "testing"
+
+// Covers chunk0-2's core contract: Position resolves a byte offset to the
+// 1-indexed line/column it falls on, and Line returns that line's text.
+func TestSourcePositionResolvesLineAndCol(t *testing.T) {
+	src := NewSource("foo(1)\nbar(2, 3)\nbaz")
+
+	cases := []struct {
+		offset int
+		line, col int
+	}{
+		{0, 1, 1},   // start of line 1
+		{3, 1, 4},   // the "(" in foo(
+		{6, 1, 7},   // the newline itself
+		{7, 2, 1},   // start of line 2
+		{11, 2, 5},  // the "2" in bar(2, 3)
+		{17, 3, 1},  // start of line 3
+		{20, 3, 4},  // end of input, one past its last byte
+	}
+
+	for _, c := range cases {
+		pos := src.Position(c.offset)
+		if pos.Line != c.line || pos.Col != c.col {
+			t.Errorf("Position(%d) = {Line:%d Col:%d}, want {Line:%d Col:%d}", c.offset, pos.Line, pos.Col, c.line, c.col)
+		}
+	}
+}
+
+func TestSourceLineReturnsLineTextWithoutNewline(t *testing.T) {
+	src := NewSource("foo(1)\nbar(2, 3)\nbaz")
+
+	want := []string{"foo(1)", "bar(2, 3)", "baz"}
+	for i, line := range want {
+		if got := src.Line(i + 1); got != line {
+			t.Errorf("Line(%d) = %q, want %q", i+1, got, line)
+		}
+	}
+
+	if got := src.Line(0); got != "" {
+		t.Errorf("Line(0) = %q, want empty string", got)
+	}
+	if got := src.Line(4); got != "" {
+		t.Errorf("Line(4) = %q, want empty string", got)
+	}
+}
+
+// Covers chunk0-2's stated purpose: every AstFrame carries positional
+// metadata resolved via Source, not just the frame itself tracking raw
+// offsets.
+func TestParsePopulatesLineColOnEveryFrame(t *testing.T) {
+	ast, err := Parse("do\n  foo(1)\nend")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	block := ast.Children[0]
+	if block.Line != 1 || block.Col != 1 {
+		t.Fatalf("block Line/Col = %d/%d, want 1/1", block.Line, block.Col)
+	}
+
+	call := block.Statements()[0]
+	if call.Line != 2 || call.Col != 3 {
+		t.Fatalf("call Line/Col = %d/%d, want 2/3", call.Line, call.Col)
+	}
+}
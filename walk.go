@@ -0,0 +1,217 @@
+package parser
+
+// Callee returns the function being invoked if f is a CALL_EXPRESSION, or
+// nil otherwise.
+func (f *AstFrame) Callee() *AstFrame {
+	if f == nil {
+		return nil
+	}
+	callee, _ := f.Data["Callee"].(*AstFrame)
+	return callee
+}
+
+// Arguments returns the argument list of a CALL_EXPRESSION or
+// ARG_LIST_EXPRESSION frame, or nil for any other frame.
+func (f *AstFrame) Arguments() []*AstFrame {
+	if f == nil {
+		return nil
+	}
+	arguments, _ := f.Data["Arguments"].([]*AstFrame)
+	return arguments
+}
+
+// Statements returns the folded top-level body of a ROOT or BLOCK_EXPRESSION
+// frame -- e.g. a bare `1+2` folds into a single BINARY_EXPRESSION -- or nil
+// for any other frame.
+func (f *AstFrame) Statements() []*AstFrame {
+	if f == nil {
+		return nil
+	}
+	statements, _ := f.Data["Statements"].([]*AstFrame)
+	return statements
+}
+
+// StringValue returns the string an IDENTIFIER or STRING_LITERAL frame
+// holds (its Name or Content, respectively), and whether f was one of those.
+func (f *AstFrame) StringValue() (string, bool) {
+	if f == nil {
+		return "", false
+	}
+
+	switch f.Name {
+	case IDENTIFIER:
+		name, ok := f.Data["Name"].(string)
+		return name, ok
+	case STRING_LITERAL:
+		content, ok := f.Data["Content"].(string)
+		return content, ok
+	default:
+		return "", false
+	}
+}
+
+// semanticChildren returns the frames that matter for tree-shaped
+// traversal: a CALL_EXPRESSION's callee and arguments, a BINARY_EXPRESSION's
+// operands, a ROOT/BLOCK_EXPRESSION's folded statements, and so on --
+// rather than the raw token stream in Children, which also holds
+// punctuation like parens, do/end and whitespace. Leaf tokens fall back to
+// Children (always empty for them).
+func semanticChildren(f *AstFrame) []*AstFrame {
+	switch f.Name {
+	case CALL_EXPRESSION:
+		arguments := f.Arguments()
+		children := make([]*AstFrame, 0, len(arguments)+1)
+		if callee := f.Callee(); callee != nil {
+			children = append(children, callee)
+		}
+		return append(children, arguments...)
+
+	case ARG_LIST_EXPRESSION:
+		return f.Arguments()
+
+	case ROOT, BLOCK_EXPRESSION:
+		return f.Statements()
+
+	case BINARY_EXPRESSION:
+		var children []*AstFrame
+		if left, ok := f.Data["Left"].(*AstFrame); ok && left != nil {
+			children = append(children, left)
+		}
+		if right, ok := f.Data["Right"].(*AstFrame); ok && right != nil {
+			children = append(children, right)
+		}
+		return children
+
+	case PREFIX_EXPRESSION:
+		if operand, ok := f.Data["Operand"].(*AstFrame); ok && operand != nil {
+			return []*AstFrame{operand}
+		}
+		return nil
+
+	default:
+		return f.Children
+	}
+}
+
+// Visitor's Visit method is invoked for each node Walk encounters. If the
+// result is non-nil, Walk visits the node's children with the returned
+// Visitor; returning nil prunes that subtree.
+type Visitor interface {
+	Visit(node *AstFrame) Visitor
+}
+
+// Walk traverses root in depth-first order, in the same shape as
+// go/ast.Walk: visit root, then (if Visit didn't return nil) walk each of
+// its semantic children with the returned Visitor, then call Visit(nil) to
+// signal that root is done.
+func Walk(root *AstFrame, v Visitor) {
+	if root == nil {
+		return
+	}
+
+	if v = v.Visit(root); v == nil {
+		return
+	}
+
+	for _, child := range semanticChildren(root) {
+		Walk(child, v)
+	}
+
+	v.Visit(nil)
+}
+
+// Rewrite returns a new tree built by walking root bottom-up and replacing
+// each frame with fn(frame): children (both the raw Children and any
+// semantic references in Data, e.g. Callee/Arguments/Left/Right/Operand)
+// are rewritten first, so fn always sees a frame whose substructure already
+// reflects earlier substitutions. Each original frame is passed through fn
+// exactly once -- a Data reference that aliases one of root's own Children
+// (e.g. a CALL_EXPRESSION's Arguments, which point at the same frames as its
+// raw Children) reuses that child's already-computed rewrite instead of
+// being rewritten again as a second, divergent copy.
+func Rewrite(root *AstFrame, fn func(*AstFrame) *AstFrame) *AstFrame {
+	if root == nil {
+		return nil
+	}
+
+	rewritten := root.shallowCopy()
+
+	rewritten.Children = make([]*AstFrame, len(root.Children))
+	childRewrites := make(map[*AstFrame]*AstFrame, len(root.Children))
+	for i, child := range root.Children {
+		newChild := Rewrite(child, fn)
+		if newChild != nil {
+			newChild.Parent = rewritten
+		}
+		rewritten.Children[i] = newChild
+		childRewrites[child] = newChild
+	}
+
+	rewritten.Data = rewriteData(root.Data, rewritten, childRewrites, fn)
+
+	return fn(rewritten)
+}
+
+// shallowCopy copies f's own fields but not its Children/Data, which
+// Rewrite fills in itself once their replacements are known.
+func (f *AstFrame) shallowCopy() *AstFrame {
+	return &AstFrame{
+		Name: f.Name,
+		Parent: f.Parent,
+		Pos: f.Pos,
+		Line: f.Line,
+		Col: f.Col,
+		EndPos: f.EndPos,
+		source: f.source,
+		grammar: f.grammar,
+	}
+}
+
+// rewriteData rewrites any *AstFrame or []*AstFrame values in data (e.g.
+// "Callee", "Arguments", "Left", "Right", "Operand"), reparenting them to
+// parent, and leaves everything else as-is. childRewrites is consulted
+// first (see rewriteRef) so a reference that's also one of parent's own
+// Children isn't rewritten a second time.
+func rewriteData(data map[string]interface{}, parent *AstFrame, childRewrites map[*AstFrame]*AstFrame, fn func(*AstFrame) *AstFrame) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	out := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		switch v := value.(type) {
+		case *AstFrame:
+			out[key] = rewriteRef(v, parent, childRewrites, fn)
+		case []*AstFrame:
+			items := make([]*AstFrame, len(v))
+			for i, item := range v {
+				items[i] = rewriteRef(item, parent, childRewrites, fn)
+			}
+			out[key] = items
+		default:
+			out[key] = value
+		}
+	}
+
+	return out
+}
+
+// rewriteRef resolves a Data reference to its rewritten frame. If v is also
+// one of parent's original Children, it reuses that child's already-computed
+// rewrite (already reparented to parent); otherwise it rewrites v on its own
+// and reparents it here.
+func rewriteRef(v *AstFrame, parent *AstFrame, childRewrites map[*AstFrame]*AstFrame, fn func(*AstFrame) *AstFrame) *AstFrame {
+	if v == nil {
+		return nil
+	}
+
+	if rewritten, ok := childRewrites[v]; ok {
+		return rewritten
+	}
+
+	rewritten := Rewrite(v, fn)
+	if rewritten != nil {
+		rewritten.Parent = parent
+	}
+	return rewritten
+}
@@ -0,0 +1,115 @@
+package parser
+
+import "testing"
+
+// Covers chunk0-5's core contract: Walk visits every semantic child once,
+// depth-first, in the go/ast.Walk order. The Visit(nil) calls that end each
+// subtree are ignored here, not checked.
+func TestWalkVisitsEverySemanticChildOnce(t *testing.T) {
+	ast, err := Parse(`foo("bar", 123.456)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []TokenName
+	var v visitorFunc
+	v = func(node *AstFrame) Visitor {
+		if node == nil {
+			return nil
+		}
+		names = append(names, node.Name)
+		return v
+	}
+	Walk(ast, v)
+
+	want := []TokenName{ROOT, CALL_EXPRESSION, IDENTIFIER, STRING_LITERAL, FLOAT_LITERAL}
+	if len(names) != len(want) {
+		t.Fatalf("visited %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("visited %v, want %v", names, want)
+		}
+	}
+}
+
+// visitorFunc adapts a plain func to the Visitor interface.
+type visitorFunc func(*AstFrame) Visitor
+
+func (f visitorFunc) Visit(node *AstFrame) Visitor { return f(node) }
+
+// Regression test for the double-rewrite bug: a CALL_EXPRESSION's
+// Arguments alias the exact same pointers as its raw Children, so without
+// memoizing original-frame -> rewritten-frame, each argument would be
+// passed through fn twice independently and come back as two diverging
+// copies instead of being the same rewritten node reused in both places.
+func TestRewritePassesEachFrameThroughFnExactlyOnce(t *testing.T) {
+	ast, err := Parse(`foo("bar", 123.456)`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := 0
+	rewritten := Rewrite(ast, func(f *AstFrame) *AstFrame {
+		calls++
+		return f
+	})
+
+	call := rewritten.Statements()[0]
+	args := call.Arguments()
+	if len(args) != 2 {
+		t.Fatalf("expected 2 arguments, got %d", len(args))
+	}
+
+	childSet := make(map[*AstFrame]bool, len(call.Children))
+	for _, child := range call.Children {
+		childSet[child] = true
+	}
+	for i, arg := range args {
+		if !childSet[arg] {
+			t.Fatalf("Arguments[%d] (%p) is not the same rewritten pointer as its entry in Children -- fn ran on it twice", i, arg)
+		}
+	}
+
+	// One call per distinct raw token/frame in the tree (ROOT, the call, its
+	// callee, CALL_IN, the string literal, the comma, whitespace, the float
+	// literal, CALL_OUT) -- each original frame exactly once, not twice for
+	// the arguments that alias raw Children entries.
+	want := 9
+	if calls != want {
+		t.Fatalf("fn was called %d times, want %d (once per distinct frame)", calls, want)
+	}
+}
+
+// Covers the typed accessors Walk/Rewrite are built on: Callee, Arguments,
+// Statements and StringValue all read the right Data key and fail closed
+// (nil/false/empty) for frames that don't have it.
+func TestTypedAccessorsReadTheirOwnDataKey(t *testing.T) {
+	ast, err := Parse(`foo("bar")`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	call := ast.Statements()[0]
+
+	name, ok := call.Callee().StringValue()
+	if !ok || name != "foo" {
+		t.Fatalf("Callee().StringValue() = %q, %v, want \"foo\", true", name, ok)
+	}
+
+	args := call.Arguments()
+	if len(args) != 1 {
+		t.Fatalf("expected 1 argument, got %d", len(args))
+	}
+	content, ok := args[0].StringValue()
+	if !ok || content != "bar" {
+		t.Fatalf("Arguments()[0].StringValue() = %q, %v, want \"bar\", true", content, ok)
+	}
+
+	if call.Statements() != nil {
+		t.Fatalf("Statements() on a CALL_EXPRESSION should be nil, got %v", call.Statements())
+	}
+	if call.Callee().Arguments() != nil {
+		t.Fatalf("Arguments() on an IDENTIFIER should be nil, got %v", call.Callee().Arguments())
+	}
+}